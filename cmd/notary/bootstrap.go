@@ -0,0 +1,286 @@
+package main
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/docker/vetinari/trustmanager"
+
+	"github.com/spf13/cobra"
+)
+
+var cmdKeysBootstrap = &cobra.Command{
+	Use:   "bootstrap",
+	Short: "Materializes a CA hierarchy from a JSON config.",
+	Long:  "builds an entire root/intermediate/leaf CA hierarchy in one shot from a declarative JSON config. Re-running is idempotent: certificates that already exist are skipped.",
+	Run:   keysBootstrap,
+}
+
+func init() {
+	cmdKeys.AddCommand(cmdKeysBootstrap)
+	cmdKeysBootstrap.Flags().String("config", "", "path to the CA hierarchy JSON config")
+}
+
+// caHierarchyNode is one node of the tree described by a `keys bootstrap`
+// config: a certificate to generate, plus the children it should sign.
+type caHierarchyNode struct {
+	Name     string              `json:"name"`
+	Subject  caHierarchySubject  `json:"subject"`
+	Validity caHierarchyValidity `json:"validity"`
+	KeyType  string              `json:"keyType"`
+
+	KeyUsage    []string `json:"keyUsage"`
+	ExtKeyUsage []string `json:"extKeyUsage"`
+
+	IsCA     bool              `json:"isCA"`
+	PathLen  *int              `json:"pathLen"`
+	Children []caHierarchyNode `json:"children"`
+}
+
+type caHierarchySubject struct {
+	CN string `json:"CN"`
+	O  string `json:"O"`
+	OU string `json:"OU"`
+	C  string `json:"C"`
+}
+
+type caHierarchyValidity struct {
+	NotBefore string `json:"notBefore"`
+	NotAfter  string `json:"notAfter"`
+}
+
+func keysBootstrap(cmd *cobra.Command, args []string) {
+	configPath, _ := cmd.Flags().GetString("config")
+	if configPath == "" {
+		cmd.Usage()
+		fatalf("must specify --config")
+	}
+
+	raw, err := ioutil.ReadFile(configPath)
+	if err != nil {
+		fatalf("could not read CA hierarchy config: %v", err)
+	}
+
+	var root caHierarchyNode
+	if err := json.Unmarshal(raw, &root); err != nil {
+		fatalf("could not parse CA hierarchy config: %v", err)
+	}
+
+	ks, err := configuredKeyStore()
+	if err != nil {
+		fatalf("could not initialize key backend: %v", err)
+	}
+
+	result, err := bootstrapNode(ks, root, nil, nil)
+	if err != nil {
+		fatalf("could not bootstrap CA hierarchy: %v", err)
+	}
+
+	fmt.Println("")
+	fmt.Println("# Bootstrapped CA hierarchy: ")
+	printBootstrapTree(result, 0)
+}
+
+// bootstrapResult pairs a CA hierarchy node with the certificate that was
+// generated (or found to already exist) for it, so the final summary tree
+// doesn't need to re-look anything up by CN.
+type bootstrapResult struct {
+	node     caHierarchyNode
+	cert     *x509.Certificate
+	children []*bootstrapResult
+}
+
+func printBootstrapTree(r *bootstrapResult, depth int) {
+	fmt.Print(strings.Repeat("  ", depth))
+	printCert(r.cert)
+	for _, child := range r.children {
+		printBootstrapTree(child, depth+1)
+	}
+}
+
+// bootstrapNode generates (or, idempotently, skips) node's certificate,
+// signed by parentSigner/parentCert, then recurses into its children.
+func bootstrapNode(ks trustmanager.KeyStore, node caHierarchyNode, parentCert *x509.Certificate, parentSigner crypto.Signer) (*bootstrapResult, error) {
+	existing, err := findExistingCert(node)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", node.Name, err)
+	}
+	if existing != nil {
+		fmt.Printf("skipping %s: already exists (%s)\n", node.Name, trustmanager.FingerprintCert(existing))
+		signer, err := ks.Get(string(trustmanager.FingerprintCert(existing)))
+		if err != nil {
+			return nil, fmt.Errorf("%s exists but its private key could not be loaded: %v", node.Name, err)
+		}
+		children, err := bootstrapChildren(ks, node, existing, signer)
+		if err != nil {
+			return nil, err
+		}
+		return &bootstrapResult{node: node, cert: existing, children: children}, nil
+	}
+
+	profile, err := certProfileFromNode(node)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", node.Name, err)
+	}
+
+	template := &x509.Certificate{
+		Subject: pkix.Name{
+			CommonName:         node.Subject.CN,
+			Organization:       nonEmptySlice(node.Subject.O),
+			OrganizationalUnit: nonEmptySlice(node.Subject.OU),
+			Country:            nonEmptySlice(node.Subject.C),
+		},
+		NotBefore:             profile.NotBefore,
+		NotAfter:              profile.NotAfter,
+		KeyUsage:              profile.KeyUsage,
+		ExtKeyUsage:           profile.ExtKeyUsage,
+		BasicConstraintsValid: true,
+		IsCA:                  node.IsCA,
+	}
+	if node.PathLen != nil {
+		template.MaxPathLen = *node.PathLen
+		template.MaxPathLenZero = *node.PathLen == 0
+	}
+	serialNumber, err := newSerialNumber()
+	if err != nil {
+		return nil, err
+	}
+	template.SerialNumber = serialNumber
+
+	var signer crypto.Signer
+	if typed, ok := ks.(trustmanager.TypedKeyStore); ok {
+		_, signer, err = typed.GenerateWithType(node.Name, node.KeyType)
+	} else {
+		_, signer, err = ks.Generate(node.Name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not generate key for %s: %v", node.Name, err)
+	}
+
+	signingTemplate, signingSigner := template, signer
+	if parentCert != nil {
+		signingTemplate, signingSigner = parentCert, parentSigner
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signingTemplate, signer.Public(), signingSigner)
+	if err != nil {
+		return nil, fmt.Errorf("could not sign certificate for %s: %v", node.Name, err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse generated certificate for %s: %v", node.Name, err)
+	}
+
+	if err := caStore.AddCert(cert); err != nil {
+		return nil, fmt.Errorf("could not register certificate for %s: %v", node.Name, err)
+	}
+	fmt.Printf("generated %s: ", node.Name)
+	printCert(cert)
+
+	children, err := bootstrapChildren(ks, node, cert, signer)
+	if err != nil {
+		return nil, err
+	}
+	return &bootstrapResult{node: node, cert: cert, children: children}, nil
+}
+
+func bootstrapChildren(ks trustmanager.KeyStore, node caHierarchyNode, cert *x509.Certificate, signer crypto.Signer) ([]*bootstrapResult, error) {
+	results := make([]*bootstrapResult, 0, len(node.Children))
+	for _, child := range node.Children {
+		result, err := bootstrapNode(ks, child, cert, signer)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// findExistingCert looks for a certificate already registered whose full
+// subject (CN, O, OU, C) matches node, making `keys bootstrap` idempotent.
+// A certificate sharing node's CN but not the rest of its subject is a
+// collision, not a match: returning it would silently splice an unrelated
+// certificate into the hierarchy, so that case is reported as an error
+// instead of being treated as "already bootstrapped".
+func findExistingCert(node caHierarchyNode) (*x509.Certificate, error) {
+	var cnCollision *x509.Certificate
+	for _, c := range caStore.GetCertificates() {
+		if c.Subject.CommonName != node.Subject.CN {
+			continue
+		}
+		if subjectMatches(c.Subject, node.Subject) {
+			return c, nil
+		}
+		cnCollision = c
+	}
+	if cnCollision != nil {
+		return nil, fmt.Errorf("a certificate for CN=%s already exists (%s) but its O/OU/C do not match this node's subject", node.Subject.CN, trustmanager.FingerprintCert(cnCollision))
+	}
+	return nil, nil
+}
+
+func subjectMatches(subject pkix.Name, want caHierarchySubject) bool {
+	return subject.CommonName == want.CN &&
+		equalSingle(subject.Organization, want.O) &&
+		equalSingle(subject.OrganizationalUnit, want.OU) &&
+		equalSingle(subject.Country, want.C)
+}
+
+// equalSingle compares a pkix.Name field (modeled as a slice but always
+// populated via nonEmptySlice, i.e. at most one element) against the single
+// string node subjects carry.
+func equalSingle(got []string, want string) bool {
+	if want == "" {
+		return len(got) == 0
+	}
+	return len(got) == 1 && got[0] == want
+}
+
+func certProfileFromNode(node caHierarchyNode) (trustmanager.CertProfile, error) {
+	profile := defaultCertProfile()
+	profile.IsCA = node.IsCA
+
+	if len(node.KeyUsage) > 0 {
+		usage, err := trustmanager.ParseKeyUsages(node.KeyUsage)
+		if err != nil {
+			return profile, err
+		}
+		profile.KeyUsage = usage
+	}
+	if len(node.ExtKeyUsage) > 0 {
+		eku, err := trustmanager.ParseExtKeyUsages(node.ExtKeyUsage)
+		if err != nil {
+			return profile, err
+		}
+		profile.ExtKeyUsage = eku
+	}
+	if node.Validity.NotBefore != "" || node.Validity.NotAfter != "" {
+		notBefore := node.Validity.NotBefore
+		if notBefore == "" {
+			notBefore = "now"
+		}
+		notAfter := node.Validity.NotAfter
+		if notAfter == "" {
+			notAfter = "now+730d"
+		}
+		nb, na, err := trustmanager.ParseValidity(notBefore, notAfter)
+		if err != nil {
+			return profile, err
+		}
+		profile.NotBefore, profile.NotAfter = nb, na
+	}
+	return profile, nil
+}
+
+func nonEmptySlice(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return []string{s}
+}
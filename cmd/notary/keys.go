@@ -1,9 +1,11 @@
 package main
 
 import (
+	"crypto/ecdsa"
 	"crypto/rand"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/pem"
 	"fmt"
 	"math"
 	"math/big"
@@ -32,6 +34,8 @@ func init() {
 	cmdKeys.AddCommand(cmdKeysTrust)
 	cmdKeys.AddCommand(cmdKeysRemove)
 	cmdKeys.AddCommand(cmdKeysGenerate)
+	cmdKeys.AddCommand(cmdKeysImport)
+	cmdKeys.AddCommand(cmdKeysExport)
 }
 
 var cmdKeysRemove = &cobra.Command{
@@ -55,6 +59,30 @@ var cmdKeysGenerate = &cobra.Command{
 	Run:   keysGenerate,
 }
 
+var cmdKeysImport = &cobra.Command{
+	Use:   "import [ GUN ] [ key file ]",
+	Short: "Imports an on-disk key into the configured key backend.",
+	Long:  "copies an existing on-disk private key for a GUN into the configured privBackend, e.g. a PKCS#11 token. The on-disk key file is left in place.",
+	Run:   keysImport,
+}
+
+var cmdKeysExport = &cobra.Command{
+	Use:   "export [ fingerprint ]",
+	Short: "Exports the public half of a key.",
+	Long:  "writes the public key for the given fingerprint to stdout. Only --public is supported: private key material never leaves its backend.",
+	Run:   keysExport,
+}
+
+func init() {
+	cmdKeysExport.Flags().Bool("public", false, "export the public key (required; private key material cannot be exported)")
+
+	cmdKeysGenerate.Flags().String("not-before", "", "start of the certificate's validity window, as an RFC3339 timestamp or a duration like \"now+30d\" or \"-1h\" (default: now)")
+	cmdKeysGenerate.Flags().String("not-after", "", "end of the certificate's validity window, as an RFC3339 timestamp or a duration like \"now+30d\" (default: two years from now)")
+	cmdKeysGenerate.Flags().StringSlice("key-usage", nil, "key usage bits, e.g. --key-usage digitalsignature,certsign")
+	cmdKeysGenerate.Flags().StringSlice("ext-key-usage", nil, "extended key usage bits, e.g. --ext-key-usage serverauth,clientauth")
+	cmdKeysGenerate.Flags().String("profile", "", "certificate profile shorthand: server, client, codesigning, or ca")
+}
+
 func keysRemove(cmd *cobra.Command, args []string) {
 	if len(args) < 1 {
 		cmd.Usage()
@@ -88,11 +116,14 @@ func keysTrust(cmd *cobra.Command, args []string) {
 
 	gun := args[0]
 	certLocationStr := args[1]
+
+	var cert *x509.Certificate
+	fromURL := false
 	// Verify if argument is a valid URL
 	url, err := url.Parse(certLocationStr)
 	if err == nil && url.Scheme != "" {
-
-		cert, err := trustmanager.GetCertFromURL(certLocationStr)
+		fromURL = true
+		cert, err = trustmanager.GetCertFromURL(certLocationStr)
 		if err != nil {
 			fatalf("error retreiving certificate from url (%s): %v", certLocationStr, err)
 		}
@@ -100,19 +131,31 @@ func keysTrust(cmd *cobra.Command, args []string) {
 		if err != nil {
 			fatalf("certificate does not match the Global Unique Name: %v", err)
 		}
-		err = caStore.AddCert(cert)
-		if err != nil {
-			fatalf("error adding certificate from file: %v", err)
-		}
-		fmt.Printf("Adding: ")
-		printCert(cert)
 	} else if _, err := os.Stat(certLocationStr); err == nil {
-		if err := caStore.AddCertFromFile(certLocationStr); err != nil {
-			fatalf("error adding certificate from file: %v", err)
+		cert, err = certFromFile(certLocationStr)
+		if err != nil {
+			fatalf("error reading certificate from file: %v", err)
 		}
 	} else {
 		fatalf("please provide a file location or URL for CA certificate.")
 	}
+
+	// Record the pinning policy before the certificate is trusted: if
+	// --pin-mode=tofu rejects a mismatched fingerprint, the certificate must
+	// never make it into caStore, or the rejection is pointless.
+	if err := pinTrust(gun, cert, cmd); err != nil {
+		fatalf("error recording trust pinning policy: %v", err)
+	}
+
+	if fromURL {
+		if err := caStore.AddCert(cert); err != nil {
+			fatalf("error adding certificate from file: %v", err)
+		}
+	} else if err := caStore.AddCertFromFile(certLocationStr); err != nil {
+		fatalf("error adding certificate from file: %v", err)
+	}
+	fmt.Printf("Adding: ")
+	printCert(cert)
 }
 
 func keysList(cmd *cobra.Command, args []string) {
@@ -122,38 +165,31 @@ func keysList(cmd *cobra.Command, args []string) {
 	}
 
 	fmt.Println("# Trusted Root keys: ")
+	pinCfg, err := trustmanager.LoadTrustPinConfig(viper.GetString("trustDir"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: could not load trust pinning config: %v\n", err)
+		pinCfg = &trustmanager.TrustPinConfig{}
+	}
 	trustedCAs := caStore.GetCertificates()
 	for _, c := range trustedCAs {
 		printCert(c)
+		if mode := pinCfg.ModeFor(c.Subject.CommonName); mode != "" {
+			fmt.Printf("  pinning: %s\n", mode)
+		}
 	}
 
 	fmt.Println("")
 	fmt.Println("# Signing keys: ")
-	filepath.Walk(viper.GetString("privDir"), printAllPrivateKeys)
-}
-
-func printAllPrivateKeys(fp string, fi os.FileInfo, err error) error {
-	// If there are errors, ignore this particular file
-	if err != nil {
-		return nil
-	}
-	// Ignore if it is a directory
-	if !!fi.IsDir() {
-		return nil
-	}
-	//TODO (diogo): make the key extension not be hardcoded
-	// Only allow matches that end with our key extension .key
-	matched, _ := filepath.Match("*.key", fi.Name())
-	if matched {
-		fp = strings.TrimSuffix(fp, filepath.Ext(fp))
-		fp = strings.TrimPrefix(fp, viper.GetString("privDir"))
-
-		fingerprint := filepath.Base(fp)
-		gun := filepath.Dir(fp)[1:]
-
-		fmt.Printf("%s %s\n", gun, fingerprint)
+	for _, ks := range configuredKeyStores() {
+		keys, err := ks.List()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: could not list keys from backend=%s: %v\n", ks.Name(), err)
+			continue
+		}
+		for _, k := range keys {
+			fmt.Printf("%s %s backend=%s\n", k.GUN, k.Fingerprint, ks.Name())
+		}
 	}
-	return nil
 }
 
 func keysGenerate(cmd *cobra.Command, args []string) {
@@ -165,22 +201,106 @@ func keysGenerate(cmd *cobra.Command, args []string) {
 	// (diogo): Validate GUNs
 	gun := args[0]
 
-	_, cert, err := generateKeyAndCert(gun)
+	ks, err := configuredKeyStore()
+	if err != nil {
+		fatalf("could not initialize key backend: %v", err)
+	}
+
+	fingerprint, signer, err := ks.Generate(gun)
 	if err != nil {
 		fatalf("could not generate key: %v", err)
 	}
 
+	profile, err := certProfileFromFlags(cmd)
+	if err != nil {
+		fatalf("%v", err)
+	}
+
+	template := newCertificate(gun, gun, profile)
+	der, err := x509.CreateCertificate(rand.Reader, template, template, signer.Public(), signer)
+	if err != nil {
+		fatalf("could not generate certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		fatalf("could not parse generated certificate: %v", err)
+	}
+
 	caStore.AddCert(cert)
-	fingerprint := trustmanager.FingerprintCert(cert)
-	fmt.Println("Generated new keypair with ID: ", string(fingerprint))
+	fmt.Printf("Generated new keypair with ID: %s (backend=%s)\n", fingerprint, ks.Name())
+}
+
+func keysImport(cmd *cobra.Command, args []string) {
+	if len(args) < 2 {
+		cmd.Usage()
+		fatalf("must specify a GUN and a key file")
+	}
+	gun := args[0]
+	keyFile := args[1]
+
+	src := trustmanager.NewFileKeyStore(filepath.Dir(keyFile))
+	fingerprint := strings.TrimSuffix(filepath.Base(keyFile), filepath.Ext(keyFile))
+	signer, err := src.Get(fingerprint)
+	if err != nil {
+		fatalf("could not read key to import: %v", err)
+	}
+	priv, ok := signer.(*ecdsa.PrivateKey)
+	if !ok {
+		fatalf("only ECDSA keys can be imported")
+	}
+
+	dst, err := configuredKeyStore()
+	if err != nil {
+		fatalf("could not initialize key backend: %v", err)
+	}
+	importer, ok := dst.(trustmanager.Importer)
+	if !ok {
+		fatalf("configured privBackend=%s does not support importing keys", dst.Name())
+	}
+
+	newFingerprint, err := importer.Import(gun, priv)
+	if err != nil {
+		fatalf("could not import key: %v", err)
+	}
+
+	fmt.Printf("Imported key for %s into backend=%s (fingerprint: %s)\n", gun, dst.Name(), newFingerprint)
 }
 
-func newCertificate(gun, organization string) *x509.Certificate {
-	notBefore := time.Now()
-	notAfter := notBefore.Add(time.Hour * 24 * 365 * 2)
+func keysExport(cmd *cobra.Command, args []string) {
+	public, _ := cmd.Flags().GetBool("public")
+	if !public {
+		fatalf("only --public export is supported; private key material cannot leave its backend")
+	}
+	if len(args) < 1 {
+		cmd.Usage()
+		fatalf("must specify a fingerprint")
+	}
+	fingerprint := args[0]
+
+	ks, err := configuredKeyStore()
+	if err != nil {
+		fatalf("could not initialize key backend: %v", err)
+	}
+	signer, err := ks.Get(fingerprint)
+	if err != nil {
+		fatalf("could not find key: %v", err)
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(signer.Public())
+	if err != nil {
+		fatalf("could not marshal public key: %v", err)
+	}
+	pem.Encode(os.Stdout, &pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
 
+// newSerialNumber returns a random 128-bit certificate serial number.
+func newSerialNumber() (*big.Int, error) {
 	serialNumberLimit := new(big.Int).Lsh(big.NewInt(1), 128)
-	serialNumber, err := rand.Int(rand.Reader, serialNumberLimit)
+	return rand.Int(rand.Reader, serialNumberLimit)
+}
+
+func newCertificate(gun, organization string, profile trustmanager.CertProfile) *x509.Certificate {
+	serialNumber, err := newSerialNumber()
 	if err != nil {
 		fatalf("failed to generate serial number: %s", err)
 	}
@@ -191,12 +311,13 @@ func newCertificate(gun, organization string) *x509.Certificate {
 			Organization: []string{organization},
 			CommonName:   gun,
 		},
-		NotBefore: notBefore,
-		NotAfter:  notAfter,
+		NotBefore: profile.NotBefore,
+		NotAfter:  profile.NotAfter,
 
-		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
-		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		KeyUsage:              profile.KeyUsage,
+		ExtKeyUsage:           profile.ExtKeyUsage,
 		BasicConstraintsValid: true,
+		IsCA:                  profile.IsCA,
 	}
 }
 
@@ -204,4 +325,4 @@ func printCert(cert *x509.Certificate) {
 	timeDifference := cert.NotAfter.Sub(time.Now())
 	subjectKeyID := trustmanager.FingerprintCert(cert)
 	fmt.Printf("%s %s (expires in: %v days)\n", cert.Subject.CommonName, string(subjectKeyID), math.Floor(timeDifference.Hours()/24))
-}
\ No newline at end of file
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/docker/vetinari/trustmanager"
+
+	"github.com/spf13/viper"
+)
+
+// configuredKeyStore returns the trustmanager.KeyStore selected by the
+// "privBackend" viper setting ("file", the default, or "pkcs11").
+func configuredKeyStore() (trustmanager.KeyStore, error) {
+	backend := viper.GetString("privBackend")
+	if backend == "" {
+		backend = "file"
+	}
+
+	switch backend {
+	case "file":
+		return trustmanager.NewFileKeyStore(viper.GetString("privDir")), nil
+	case "pkcs11":
+		slot := (*int)(nil)
+		if viper.IsSet("pkcs11.slot") {
+			s := viper.GetInt("pkcs11.slot")
+			slot = &s
+		}
+		modulePath := viper.GetString("pkcs11.module")
+		if modulePath == "" {
+			modulePath = trustmanager.DefaultPKCS11ModulePath()
+		}
+		return trustmanager.NewPKCS11KeyStore(trustmanager.PKCS11Config{
+			ModulePath: modulePath,
+			TokenLabel: viper.GetString("pkcs11.tokenLabel"),
+			Pin:        viper.GetString("pkcs11.pin"),
+			Slot:       slot,
+		})
+	default:
+		fatalf("unknown privBackend %q: expected \"file\" or \"pkcs11\"", backend)
+		return nil, nil
+	}
+}
+
+// configuredKeyStores returns every KeyStore backend that has been
+// configured: the on-disk store always, plus the PKCS#11 store when a
+// token has been configured via the "pkcs11" viper section.
+func configuredKeyStores() []trustmanager.KeyStore {
+	stores := []trustmanager.KeyStore{trustmanager.NewFileKeyStore(viper.GetString("privDir"))}
+
+	if viper.IsSet("pkcs11.module") || viper.IsSet("pkcs11.tokenLabel") {
+		modulePath := viper.GetString("pkcs11.module")
+		if modulePath == "" {
+			modulePath = trustmanager.DefaultPKCS11ModulePath()
+		}
+		slot := (*int)(nil)
+		if viper.IsSet("pkcs11.slot") {
+			s := viper.GetInt("pkcs11.slot")
+			slot = &s
+		}
+		ks, err := trustmanager.NewPKCS11KeyStore(trustmanager.PKCS11Config{
+			ModulePath: modulePath,
+			TokenLabel: viper.GetString("pkcs11.tokenLabel"),
+			Pin:        viper.GetString("pkcs11.pin"),
+			Slot:       slot,
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: could not open PKCS#11 token: %v\n", err)
+		} else {
+			stores = append(stores, ks)
+		}
+	}
+
+	return stores
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"crypto/x509"
+	"fmt"
+	"time"
+
+	"github.com/docker/vetinari/trustmanager"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// defaultCertProfile reproduces notary's historical defaults: a two year
+// validity window, KeyEncipherment|DigitalSignature, and
+// ExtKeyUsageCodeSigning. It's the starting point for `keys generate` when
+// no --profile, --key-usage, --ext-key-usage, --not-before or --not-after
+// flags are given.
+func defaultCertProfile() trustmanager.CertProfile {
+	notBefore := time.Now()
+	return trustmanager.CertProfile{
+		NotBefore:   notBefore,
+		NotAfter:    notBefore.Add(time.Hour * 24 * 365 * 2),
+		KeyUsage:    x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	}
+}
+
+// resolveProfile looks up a named certificate profile, preferring a
+// site-defined "profiles.<name>" viper section over the built-in
+// server/client/codesigning/ca shorthands, so operators can ship policy
+// without touching the CLI.
+func resolveProfile(name string) (trustmanager.CertProfile, error) {
+	key := "profiles." + name
+	if !viper.IsSet(key) {
+		return trustmanager.NamedProfile(name)
+	}
+
+	profile := trustmanager.CertProfile{IsCA: viper.GetBool(key + ".isCA")}
+
+	if usages := viper.GetStringSlice(key + ".keyUsage"); len(usages) > 0 {
+		usage, err := trustmanager.ParseKeyUsages(usages)
+		if err != nil {
+			return profile, fmt.Errorf("profiles.%s.keyUsage: %v", name, err)
+		}
+		profile.KeyUsage = usage
+	}
+	if usages := viper.GetStringSlice(key + ".extKeyUsage"); len(usages) > 0 {
+		eku, err := trustmanager.ParseExtKeyUsages(usages)
+		if err != nil {
+			return profile, fmt.Errorf("profiles.%s.extKeyUsage: %v", name, err)
+		}
+		profile.ExtKeyUsage = eku
+	}
+	if notBefore := viper.GetString(key + ".notBefore"); notBefore != "" {
+		nb, na, err := trustmanager.ParseValidity(notBefore, viper.GetString(key+".notAfter"))
+		if err != nil {
+			return profile, fmt.Errorf("profiles.%s: %v", name, err)
+		}
+		profile.NotBefore, profile.NotAfter = nb, na
+	}
+
+	return profile, nil
+}
+
+// certProfileFromFlags builds the CertProfile to use for `keys generate`
+// from its --profile, --key-usage, --ext-key-usage, --not-before and
+// --not-after flags, layered on top of defaultCertProfile().
+func certProfileFromFlags(cmd *cobra.Command) (trustmanager.CertProfile, error) {
+	profile := defaultCertProfile()
+
+	if name, _ := cmd.Flags().GetString("profile"); name != "" {
+		p, err := resolveProfile(name)
+		if err != nil {
+			return profile, err
+		}
+		if p.NotBefore.IsZero() {
+			p.NotBefore, p.NotAfter = profile.NotBefore, profile.NotAfter
+		}
+		profile = p
+	}
+
+	if usages, _ := cmd.Flags().GetStringSlice("key-usage"); len(usages) > 0 {
+		usage, err := trustmanager.ParseKeyUsages(usages)
+		if err != nil {
+			return profile, err
+		}
+		profile.KeyUsage = usage
+	}
+	if usages, _ := cmd.Flags().GetStringSlice("ext-key-usage"); len(usages) > 0 {
+		eku, err := trustmanager.ParseExtKeyUsages(usages)
+		if err != nil {
+			return profile, err
+		}
+		profile.ExtKeyUsage = eku
+	}
+
+	notBeforeSpec, _ := cmd.Flags().GetString("not-before")
+	notAfterSpec, _ := cmd.Flags().GetString("not-after")
+	if notBeforeSpec != "" || notAfterSpec != "" {
+		if notBeforeSpec == "" {
+			notBeforeSpec = "now"
+		}
+		if notAfterSpec == "" {
+			notAfterSpec = "now+730d"
+		}
+		notBefore, notAfter, err := trustmanager.ParseValidity(notBeforeSpec, notAfterSpec)
+		if err != nil {
+			return profile, err
+		}
+		profile.NotBefore, profile.NotAfter = notBefore, notAfter
+	}
+
+	return profile, nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/docker/vetinari/client"
+	"github.com/docker/vetinari/client/changelist"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var cmdKeysStatus = &cobra.Command{
+	Use:   "status [ GUN ]",
+	Short: "Shows the unpublished changelist for a GUN.",
+	Long:  "prints the local TUF changelist for a GUN that has not yet been pushed to the remote server, analogous to `git status`.",
+	Run:   keysStatus,
+}
+
+func init() {
+	cmdKeys.AddCommand(cmdKeysStatus)
+	cmdKeysStatus.Flags().Bool("json", false, "emit the changelist as a JSON array")
+}
+
+func keysStatus(cmd *cobra.Command, args []string) {
+	if len(args) < 1 {
+		cmd.Usage()
+		fatalf("must specify a GUN")
+	}
+	gun := args[0]
+
+	repo, err := client.NewFileCachedRepository(
+		viper.GetString("trustDir"),
+		gun,
+		viper.GetString("remoteServer"),
+		nil,
+		nil,
+	)
+	if err != nil {
+		fatalf("could not open repository for %s: %v", gun, err)
+	}
+
+	cl, err := repo.GetChangelist()
+	if err != nil {
+		fatalf("could not read changelist for %s: %v", gun, err)
+	}
+	changes := cl.List()
+
+	if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+		printChangelistJSON(changes)
+		return
+	}
+
+	if len(changes) == 0 {
+		fmt.Printf("No unpublished changes for %s\n", gun)
+		return
+	}
+
+	fmt.Printf("# Unpublished changes for %s: \n", gun)
+	fmt.Printf("%-10s %-10s %-10s %s\n", "action", "scope", "type", "path")
+	for _, c := range changes {
+		fmt.Printf("%-10s %-10s %-10s %s\n", c.Action(), c.Scope(), c.Type(), c.Path())
+	}
+}
+
+type changeRecord struct {
+	Action string `json:"action"`
+	Scope  string `json:"scope"`
+	Type   string `json:"type"`
+	Path   string `json:"path"`
+}
+
+func printChangelistJSON(changes []changelist.Change) {
+	records := make([]changeRecord, 0, len(changes))
+	for _, c := range changes {
+		records = append(records, changeRecord{
+			Action: c.Action(),
+			Scope:  c.Scope(),
+			Type:   c.Type(),
+			Path:   c.Path(),
+		})
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(records)
+}
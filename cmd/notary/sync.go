@@ -0,0 +1,106 @@
+package main
+
+import (
+	"crypto/x509"
+	"fmt"
+
+	"github.com/docker/vetinari/trustmanager"
+
+	"github.com/spf13/cobra"
+)
+
+var cmdKeysSync = &cobra.Command{
+	Use:   "sync",
+	Short: "Reconciles the trust store against an external bundle.",
+	Long:  "converges the local caStore with an externally supplied bundle of trusted certificates (a PEM file, a directory, or an HTTPS URL serving a concatenated PEM), adding and removing certificates so the two agree.",
+	Run:   keysSync,
+}
+
+func init() {
+	cmdKeys.AddCommand(cmdKeysSync)
+	cmdKeysSync.Flags().String("from", "", "PEM file, directory, or HTTPS URL to sync the trust store against")
+	cmdKeysSync.Flags().Bool("dry-run", false, "print the planned add/remove set without changing the trust store")
+	cmdKeysSync.Flags().Bool("prune", true, "remove local certificates not present in the bundle (set false for additive-only sync)")
+	cmdKeysSync.Flags().String("gun-filter", "", "only sync certificates whose GUN matches this prefix glob")
+}
+
+func keysSync(cmd *cobra.Command, args []string) {
+	from, _ := cmd.Flags().GetString("from")
+	if from == "" {
+		cmd.Usage()
+		fatalf("must specify --from")
+	}
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	prune, _ := cmd.Flags().GetBool("prune")
+	gunFilter, _ := cmd.Flags().GetString("gun-filter")
+
+	bundle, err := trustmanager.LoadCertBundle(from)
+	if err != nil {
+		fatalf("could not load cert bundle from %s: %v", from, err)
+	}
+
+	oldCertMap := certMapByFingerprint(caStore.GetCertificates(), gunFilter)
+	newCertMap := certMapByFingerprint(bundle, gunFilter)
+
+	var toAdd, toRemove []*x509.Certificate
+	for fp, cert := range newCertMap {
+		if _, ok := oldCertMap[fp]; !ok {
+			toAdd = append(toAdd, cert)
+		}
+	}
+	for fp, cert := range oldCertMap {
+		if _, ok := newCertMap[fp]; !ok {
+			toRemove = append(toRemove, cert)
+		}
+	}
+
+	if dryRun {
+		fmt.Println("# Would add: ")
+		for _, cert := range toAdd {
+			printCert(cert)
+		}
+		fmt.Println("")
+		if prune {
+			fmt.Println("# Would remove: ")
+			for _, cert := range toRemove {
+				printCert(cert)
+			}
+		} else {
+			fmt.Println("# --prune=false: nothing would be removed")
+		}
+		return
+	}
+
+	for _, cert := range toAdd {
+		if err := caStore.AddCert(cert); err != nil {
+			fatalf("could not add certificate for %s: %v", cert.Subject.CommonName, err)
+		}
+		fmt.Printf("Added: ")
+		printCert(cert)
+	}
+
+	if !prune {
+		return
+	}
+	for _, cert := range toRemove {
+		if err := caStore.RemoveCert(cert); err != nil {
+			fatalf("could not remove certificate for %s: %v", cert.Subject.CommonName, err)
+		}
+		fmt.Printf("Removed: ")
+		printCert(cert)
+	}
+}
+
+// certMapByFingerprint indexes certs by SubjectKeyID fingerprint, optionally
+// restricted to GUNs matching gunFilter, to compute the symmetric
+// difference between two trust sets.
+func certMapByFingerprint(certs []*x509.Certificate, gunFilter string) map[string]*x509.Certificate {
+	certMap := map[string]*x509.Certificate{}
+	for _, cert := range certs {
+		if gunFilter != "" && !trustmanager.MatchGUNPrefix(gunFilter, cert.Subject.CommonName) {
+			continue
+		}
+		certMap[string(trustmanager.FingerprintCert(cert))] = cert
+	}
+	return certMap
+}
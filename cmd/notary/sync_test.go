@@ -0,0 +1,96 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/docker/vetinari/trustmanager"
+)
+
+func selfSignedCert(t *testing.T, cn string) *x509.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("could not generate test key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("could not create test certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("could not parse test certificate: %v", err)
+	}
+	return cert
+}
+
+func TestCertMapByFingerprintIndexesEveryCert(t *testing.T) {
+	certs := []*x509.Certificate{
+		selfSignedCert(t, "docker.io/library/redis"),
+		selfSignedCert(t, "docker.io/library/nginx"),
+	}
+
+	certMap := certMapByFingerprint(certs, "")
+	if len(certMap) != 2 {
+		t.Fatalf("got %d entries, want 2", len(certMap))
+	}
+	for _, cert := range certs {
+		if certMap[string(trustmanager.FingerprintCert(cert))] == nil {
+			t.Errorf("missing entry for %s", cert.Subject.CommonName)
+		}
+	}
+}
+
+func TestCertMapByFingerprintAppliesGUNFilter(t *testing.T) {
+	redis := selfSignedCert(t, "docker.io/library/redis")
+	nginx := selfSignedCert(t, "docker.io/library/nginx")
+	certs := []*x509.Certificate{redis, nginx}
+
+	certMap := certMapByFingerprint(certs, "docker.io/library/redis")
+	if len(certMap) != 1 {
+		t.Fatalf("got %d entries, want 1", len(certMap))
+	}
+	if certMap[string(trustmanager.FingerprintCert(redis))] == nil {
+		t.Error("expected the matching GUN to be present")
+	}
+}
+
+func TestCertMapByFingerprintComputesSymmetricDifference(t *testing.T) {
+	shared := selfSignedCert(t, "docker.io/library/redis")
+	onlyOld := selfSignedCert(t, "docker.io/library/nginx")
+	onlyNew := selfSignedCert(t, "docker.io/library/alpine")
+
+	oldMap := certMapByFingerprint([]*x509.Certificate{shared, onlyOld}, "")
+	newMap := certMapByFingerprint([]*x509.Certificate{shared, onlyNew}, "")
+
+	var toAdd, toRemove int
+	for fp := range newMap {
+		if _, ok := oldMap[fp]; !ok {
+			toAdd++
+		}
+	}
+	for fp := range oldMap {
+		if _, ok := newMap[fp]; !ok {
+			toRemove++
+		}
+	}
+
+	if toAdd != 1 {
+		t.Errorf("got %d to add, want 1", toAdd)
+	}
+	if toRemove != 1 {
+		t.Errorf("got %d to remove, want 1", toRemove)
+	}
+}
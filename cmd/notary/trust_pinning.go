@@ -0,0 +1,133 @@
+package main
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/docker/vetinari/trustmanager"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var cmdKeysPinning = &cobra.Command{
+	Use:   "pinning",
+	Short: "Operates on the trust pinning policy.",
+	Long:  "lists or removes entries from the trust pinning policy recorded by `keys trust`.",
+}
+
+var cmdKeysPinningList = &cobra.Command{
+	Use:   "list",
+	Short: "Lists the trust pinning policy.",
+	Long:  "prints every pinned certificate and CA prefix in the trust pinning policy.",
+	Run:   keysPinningList,
+}
+
+var cmdKeysPinningRemove = &cobra.Command{
+	Use:   "remove [ GUN ]",
+	Short: "Removes trust pinning entries for a GUN.",
+	Long:  "removes any pinned certificates and matching CA prefix pin recorded for a GUN.",
+	Run:   keysPinningRemove,
+}
+
+func init() {
+	cmdKeys.AddCommand(cmdKeysPinning)
+	cmdKeysPinning.AddCommand(cmdKeysPinningList)
+	cmdKeysPinning.AddCommand(cmdKeysPinningRemove)
+
+	cmdKeysTrust.Flags().String("pin-mode", "certs", "trust pinning mode: certs, ca, or tofu")
+	cmdKeysTrust.Flags().String("pin-gun-prefix", "", "GUN prefix glob this certificate is trusted to sign as a CA with --pin-mode=ca (default: the GUN itself)")
+}
+
+// certFromFile reads and parses the first PEM-encoded certificate in path.
+func certFromFile(path string) (*x509.Certificate, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, fmt.Errorf("%s does not contain a valid PEM certificate", path)
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// pinTrust records the pinning policy for gun's newly trusted cert
+// according to cmd's --pin-mode and --pin-gun-prefix flags.
+func pinTrust(gun string, cert *x509.Certificate, cmd *cobra.Command) error {
+	mode, _ := cmd.Flags().GetString("pin-mode")
+	prefix, _ := cmd.Flags().GetString("pin-gun-prefix")
+
+	cfg, err := trustmanager.LoadTrustPinConfig(viper.GetString("trustDir"))
+	if err != nil {
+		return err
+	}
+
+	fingerprint := string(trustmanager.FingerprintCert(cert))
+
+	switch mode {
+	case "certs":
+		cfg.PinCert(gun, fingerprint)
+	case "tofu":
+		if err := cfg.PinTOFU(gun, fingerprint); err != nil {
+			return err
+		}
+	case "ca":
+		if prefix == "" {
+			prefix = gun
+		}
+		cfg.PinCA(prefix, fingerprint)
+	default:
+		return fmt.Errorf("unknown --pin-mode %q: expected certs, ca, or tofu", mode)
+	}
+
+	return cfg.Save(viper.GetString("trustDir"))
+}
+
+func keysPinningList(cmd *cobra.Command, args []string) {
+	cfg, err := trustmanager.LoadTrustPinConfig(viper.GetString("trustDir"))
+	if err != nil {
+		fatalf("could not load trust pinning config: %v", err)
+	}
+
+	fmt.Println("# Certificate pins: ")
+	for gun, fingerprints := range cfg.Certs {
+		for _, fp := range fingerprints {
+			fmt.Printf("%s %s mode=certs\n", gun, fp)
+		}
+	}
+
+	fmt.Println("")
+	fmt.Println("# CA pins: ")
+	for prefix, fingerprint := range cfg.CA {
+		fmt.Printf("%s %s mode=ca\n", prefix, fingerprint)
+	}
+
+	fmt.Println("")
+	fmt.Println("# Trust-on-first-use acceptances: ")
+	for gun, fingerprint := range cfg.TOFU {
+		fmt.Printf("%s %s mode=tofu\n", gun, fingerprint)
+	}
+}
+
+func keysPinningRemove(cmd *cobra.Command, args []string) {
+	if len(args) < 1 {
+		cmd.Usage()
+		fatalf("must specify a GUN")
+	}
+	gun := args[0]
+
+	cfg, err := trustmanager.LoadTrustPinConfig(viper.GetString("trustDir"))
+	if err != nil {
+		fatalf("could not load trust pinning config: %v", err)
+	}
+
+	cfg.Unpin(gun)
+
+	if err := cfg.Save(viper.GetString("trustDir")); err != nil {
+		fatalf("could not save trust pinning config: %v", err)
+	}
+	fmt.Printf("Removed trust pinning entries for %s\n", gun)
+}
@@ -0,0 +1,93 @@
+package trustmanager
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LoadCertBundle loads every PEM-encoded certificate found at source, which
+// may be a single PEM file, a directory (every file within is read), or an
+// http(s):// URL serving a concatenated PEM bundle. It's used by `keys
+// sync` to reconcile the local trust store against an externally
+// maintained set of trusted certificates.
+func LoadCertBundle(source string) ([]*x509.Certificate, error) {
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		return certBundleFromURL(source)
+	}
+
+	fi, err := os.Stat(source)
+	if err != nil {
+		return nil, fmt.Errorf("could not stat %s: %v", source, err)
+	}
+	if fi.IsDir() {
+		return certBundleFromDir(source)
+	}
+	return certBundleFromFile(source)
+}
+
+func certBundleFromURL(url string) ([]*x509.Certificate, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch cert bundle from %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("could not fetch cert bundle from %s: %s", url, resp.Status)
+	}
+
+	raw, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("could not read cert bundle from %s: %v", url, err)
+	}
+	return parseCertBundle(raw)
+}
+
+func certBundleFromDir(dir string) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	err := filepath.Walk(dir, func(fp string, fi os.FileInfo, err error) error {
+		if err != nil || fi.IsDir() {
+			return err
+		}
+		fileCerts, err := certBundleFromFile(fp)
+		if err != nil {
+			return err
+		}
+		certs = append(certs, fileCerts...)
+		return nil
+	})
+	return certs, err
+}
+
+func certBundleFromFile(path string) ([]*x509.Certificate, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s: %v", path, err)
+	}
+	return parseCertBundle(raw)
+}
+
+func parseCertBundle(raw []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, raw = pem.Decode(raw)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse certificate: %v", err)
+		}
+		certs = append(certs, cert)
+	}
+	return certs, nil
+}
@@ -0,0 +1,24 @@
+package trustmanager
+
+import (
+	"crypto"
+	"crypto/sha256"
+	"crypto/x509"
+	"fmt"
+)
+
+// CertID is the type of a certificate/public key fingerprint, as returned by
+// FingerprintCert and FingerprintPublicKey.
+type CertID string
+
+// FingerprintPublicKey returns the SHA256 fingerprint of a public key's
+// DER encoding. It mirrors FingerprintCert for keypairs that don't have a
+// certificate yet, such as a key freshly generated inside an HSM.
+func FingerprintPublicKey(pub crypto.PublicKey) CertID {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(der)
+	return CertID(fmt.Sprintf("%x", sum))
+}
@@ -0,0 +1,66 @@
+package trustmanager
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+)
+
+// KeyInfo describes a keypair held by a KeyStore, independent of where the
+// private key material actually lives.
+type KeyInfo struct {
+	GUN         string
+	Fingerprint string
+	Backend     string
+}
+
+// KeyStore abstracts the generation, storage and use of private signing
+// keys so that callers never need to know whether a key lives in a file on
+// disk or inside a hardware token. Implementations must never expose raw
+// private key material for backends that don't allow it (e.g. PKCS#11); the
+// crypto.Signer returned by Generate and Get is sufficient for building and
+// signing x509 certificates.
+type KeyStore interface {
+	// Generate creates a new keypair for gun and returns its fingerprint
+	// together with a crypto.Signer that can be used to sign a certificate
+	// template for it.
+	Generate(gun string) (fingerprint string, signer crypto.Signer, err error)
+
+	// Get returns a crypto.Signer for the keypair identified by
+	// fingerprint.
+	Get(fingerprint string) (crypto.Signer, error)
+
+	// List enumerates every keypair held by this store.
+	List() ([]KeyInfo, error)
+
+	// Remove deletes the keypair identified by fingerprint. Backends that
+	// cannot delete key material (e.g. some HSMs) may return an error.
+	Remove(fingerprint string) error
+
+	// Name identifies the backend for display purposes, e.g. "file" or
+	// "pkcs11:token/notary".
+	Name() string
+}
+
+// Importer is implemented by KeyStore backends that can accept raw private
+// key material from elsewhere, e.g. a PKCS#11 token importing a key
+// previously generated on disk via `notary keys import`.
+type Importer interface {
+	Import(gun string, key *ecdsa.PrivateKey) (fingerprint string, err error)
+}
+
+// TypedKeyStore is implemented by KeyStore backends that can generate a
+// keypair of a specific type, e.g. for a `keys bootstrap` CA hierarchy that
+// mixes ecdsa-p256, ecdsa-p384 and rsa-4096 keys at different levels.
+// Backends that only ever generate one key type (the common case) need not
+// implement it; callers fall back to Generate.
+type TypedKeyStore interface {
+	KeyStore
+	GenerateWithType(gun, keyType string) (fingerprint string, signer crypto.Signer, err error)
+}
+
+// KeyType names accepted by TypedKeyStore.GenerateWithType.
+const (
+	KeyTypeECDSAP256 = "ecdsa-p256"
+	KeyTypeECDSAP384 = "ecdsa-p384"
+	KeyTypeRSA4096   = "rsa-4096"
+)
@@ -0,0 +1,180 @@
+package trustmanager
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileKeyStore is the original on-disk KeyStore implementation: private
+// keys are stored PEM-encoded at <baseDir>/<gun>/<fingerprint>.key.
+type FileKeyStore struct {
+	baseDir string
+}
+
+// NewFileKeyStore returns a KeyStore backed by PEM files under baseDir.
+func NewFileKeyStore(baseDir string) *FileKeyStore {
+	return &FileKeyStore{baseDir: baseDir}
+}
+
+// Name identifies this backend for display purposes.
+func (s *FileKeyStore) Name() string {
+	return "file"
+}
+
+// Generate creates a new ECDSA P-256 keypair for gun and persists it to
+// disk, returning a signer backed by the in-memory private key.
+func (s *FileKeyStore) Generate(gun string) (string, crypto.Signer, error) {
+	return s.GenerateWithType(gun, KeyTypeECDSAP256)
+}
+
+// GenerateWithType creates a new keypair of the given KeyType for gun and
+// persists it to disk, returning a signer backed by the in-memory private
+// key.
+func (s *FileKeyStore) GenerateWithType(gun, keyType string) (string, crypto.Signer, error) {
+	priv, pub, err := generateKeyOfType(keyType)
+	if err != nil {
+		return "", nil, err
+	}
+
+	fingerprint := string(FingerprintPublicKey(pub))
+
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to marshal private key: %v", err)
+	}
+	block := &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+
+	keyPath := filepath.Join(s.baseDir, gun, fingerprint+".key")
+	if err := os.MkdirAll(filepath.Dir(keyPath), 0700); err != nil {
+		return "", nil, fmt.Errorf("failed to create key directory: %v", err)
+	}
+	if err := ioutil.WriteFile(keyPath, pem.EncodeToMemory(block), 0600); err != nil {
+		return "", nil, fmt.Errorf("failed to write private key: %v", err)
+	}
+
+	return fingerprint, priv.(crypto.Signer), nil
+}
+
+// generateKeyOfType creates a new private key of the requested KeyType,
+// returning it both as a crypto.Signer-ish interface for marshaling and as
+// its public key for fingerprinting.
+func generateKeyOfType(keyType string) (interface{ Public() crypto.PublicKey }, crypto.PublicKey, error) {
+	switch keyType {
+	case "", KeyTypeECDSAP256:
+		priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to generate ECDSA P-256 key: %v", err)
+		}
+		return priv, &priv.PublicKey, nil
+	case KeyTypeECDSAP384:
+		priv, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to generate ECDSA P-384 key: %v", err)
+		}
+		return priv, &priv.PublicKey, nil
+	case KeyTypeRSA4096:
+		priv, err := rsa.GenerateKey(rand.Reader, 4096)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to generate RSA-4096 key: %v", err)
+		}
+		return priv, &priv.PublicKey, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown key type %q: expected one of %s, %s, %s", keyType, KeyTypeECDSAP256, KeyTypeECDSAP384, KeyTypeRSA4096)
+	}
+}
+
+// Get loads the private key for fingerprint from disk and returns it as a
+// crypto.Signer.
+func (s *FileKeyStore) Get(fingerprint string) (crypto.Signer, error) {
+	var found crypto.Signer
+	err := filepath.Walk(s.baseDir, func(fp string, fi os.FileInfo, err error) error {
+		if err != nil || fi.IsDir() {
+			return nil
+		}
+		if strings.TrimSuffix(fi.Name(), filepath.Ext(fi.Name())) != fingerprint {
+			return nil
+		}
+		pemBytes, err := ioutil.ReadFile(fp)
+		if err != nil {
+			return err
+		}
+		block, _ := pem.Decode(pemBytes)
+		if block == nil {
+			return fmt.Errorf("%s does not contain a valid PEM block", fp)
+		}
+
+		if block.Type == "EC PRIVATE KEY" {
+			priv, err := x509.ParseECPrivateKey(block.Bytes)
+			if err != nil {
+				return err
+			}
+			found = priv
+			return filepath.SkipDir
+		}
+
+		priv, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return err
+		}
+		signer, ok := priv.(crypto.Signer)
+		if !ok {
+			return fmt.Errorf("%s does not contain a signing key", fp)
+		}
+		found = signer
+		return filepath.SkipDir
+	})
+	if err != nil && err != filepath.SkipDir {
+		return nil, err
+	}
+	if found == nil {
+		return nil, fmt.Errorf("no key found for fingerprint %s", fingerprint)
+	}
+	return found, nil
+}
+
+// List enumerates every keypair stored under baseDir.
+func (s *FileKeyStore) List() ([]KeyInfo, error) {
+	var keys []KeyInfo
+	err := filepath.Walk(s.baseDir, func(fp string, fi os.FileInfo, err error) error {
+		if err != nil || fi.IsDir() {
+			return nil
+		}
+		matched, _ := filepath.Match("*.key", fi.Name())
+		if !matched {
+			return nil
+		}
+		rel := strings.TrimSuffix(fp, filepath.Ext(fp))
+		rel = strings.TrimPrefix(rel, s.baseDir)
+		keys = append(keys, KeyInfo{
+			GUN:         filepath.Dir(rel)[1:],
+			Fingerprint: filepath.Base(rel),
+			Backend:     s.Name(),
+		})
+		return nil
+	})
+	return keys, err
+}
+
+// Remove deletes the private key file for fingerprint.
+func (s *FileKeyStore) Remove(fingerprint string) error {
+	keys, err := s.List()
+	if err != nil {
+		return err
+	}
+	for _, k := range keys {
+		if k.Fingerprint == fingerprint {
+			return os.Remove(filepath.Join(s.baseDir, k.GUN, fingerprint+".key"))
+		}
+	}
+	return fmt.Errorf("no key found for fingerprint %s", fingerprint)
+}
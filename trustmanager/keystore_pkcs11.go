@@ -0,0 +1,164 @@
+package trustmanager
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"fmt"
+
+	"github.com/ThalesIgnite/crypto11"
+)
+
+// PKCS11KeyStore is a KeyStore backed by a PKCS#11 token (SoftHSM, YubiKey,
+// a cloud HSM, ...). Private key material never leaves the token; callers
+// only ever see a crypto.Signer handle into it.
+type PKCS11KeyStore struct {
+	ctx        *crypto11.Context
+	tokenLabel string
+}
+
+// PKCS11Config carries the information needed to open a session against a
+// PKCS#11 token, as loaded from viper's "pkcs11" section.
+type PKCS11Config struct {
+	ModulePath string
+	TokenLabel string
+	Pin        string
+	Slot       *int
+}
+
+// NewPKCS11KeyStore opens a session against the token described by cfg.
+func NewPKCS11KeyStore(cfg PKCS11Config) (*PKCS11KeyStore, error) {
+	ctx, err := crypto11.Configure(&crypto11.Config{
+		Path:       cfg.ModulePath,
+		TokenLabel: cfg.TokenLabel,
+		Pin:        cfg.Pin,
+		SlotNumber: cfg.Slot,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize PKCS#11 module %s: %v", cfg.ModulePath, err)
+	}
+	return &PKCS11KeyStore{ctx: ctx, tokenLabel: cfg.TokenLabel}, nil
+}
+
+// Name identifies this backend for display purposes, e.g.
+// "pkcs11:token/notary".
+func (s *PKCS11KeyStore) Name() string {
+	return fmt.Sprintf("pkcs11:token/%s", s.tokenLabel)
+}
+
+// Generate asks the token to generate a P-256 ECDSA keypair for gun. The
+// private key handle is labeled with gun and identified by the key's own
+// fingerprint as its CKA_ID, and never leaves the device.
+func (s *PKCS11KeyStore) Generate(gun string) (string, crypto.Signer, error) {
+	return s.GenerateWithType(gun, KeyTypeECDSAP256)
+}
+
+// GenerateWithType asks the token to generate a keypair of the given
+// KeyType for gun. The private key handle keeps gun as its CKA_LABEL and
+// ends up identified by its own fingerprint as its CKA_ID; the key material
+// never leaves the device.
+func (s *PKCS11KeyStore) GenerateWithType(gun, keyType string) (string, crypto.Signer, error) {
+	tmpID, err := newTempKeyID()
+	if err != nil {
+		return "", nil, err
+	}
+
+	var signer crypto.Signer
+	switch keyType {
+	case "", KeyTypeECDSAP256:
+		signer, err = s.ctx.GenerateECDSAKeyPairWithLabel(tmpID, []byte(gun), elliptic.P256())
+	case KeyTypeECDSAP384:
+		signer, err = s.ctx.GenerateECDSAKeyPairWithLabel(tmpID, []byte(gun), elliptic.P384())
+	case KeyTypeRSA4096:
+		signer, err = s.ctx.GenerateRSAKeyPairWithLabel(tmpID, []byte(gun), 4096)
+	default:
+		return "", nil, fmt.Errorf("unknown key type %q: expected one of %s, %s, %s", keyType, KeyTypeECDSAP256, KeyTypeECDSAP384, KeyTypeRSA4096)
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to generate keypair on token %s: %v", s.tokenLabel, err)
+	}
+
+	fingerprint := string(FingerprintPublicKey(signer.Public()))
+	if err := s.ctx.SetKeyPairID(tmpID, []byte(fingerprint)); err != nil {
+		return "", nil, fmt.Errorf("failed to set CKA_ID on generated keypair: %v", err)
+	}
+
+	return fingerprint, signer, nil
+}
+
+// newTempKeyID returns a random 16-byte CKA_ID to generate or import a
+// keypair under, before its real fingerprint is known. It must be unique
+// per key (not derived from the GUN) so that rotating a GUN's key — the
+// file backend's `<gun>/<fp>.key` layout explicitly supports multiple keys
+// per GUN — never collides with a previous generation on the token.
+func newTempKeyID() ([]byte, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, fmt.Errorf("failed to generate a temporary key ID: %v", err)
+	}
+	return id, nil
+}
+
+// Get returns a crypto.Signer backed by the token's private key identified
+// by fingerprint.
+func (s *PKCS11KeyStore) Get(fingerprint string) (crypto.Signer, error) {
+	signer, err := s.ctx.FindKeyPair([]byte(fingerprint), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find keypair %s on token %s: %v", fingerprint, s.tokenLabel, err)
+	}
+	if signer == nil {
+		return nil, fmt.Errorf("no key found for fingerprint %s", fingerprint)
+	}
+	return signer, nil
+}
+
+// List enumerates every keypair held by the token. The GUN label stored at
+// generation time is returned alongside the fingerprint.
+func (s *PKCS11KeyStore) List() ([]KeyInfo, error) {
+	pairs, err := s.ctx.FindAllKeyPairs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to enumerate keys on token %s: %v", s.tokenLabel, err)
+	}
+
+	keys := make([]KeyInfo, 0, len(pairs))
+	for _, p := range pairs {
+		keys = append(keys, KeyInfo{
+			GUN:         string(p.Label),
+			Fingerprint: string(p.ID),
+			Backend:     s.Name(),
+		})
+	}
+	return keys, nil
+}
+
+// Import copies an on-disk private key into the token, keeping gun as its
+// CKA_LABEL and its own fingerprint (recomputed from the public key, not
+// trusted from the caller) as its CKA_ID.
+func (s *PKCS11KeyStore) Import(gun string, key *ecdsa.PrivateKey) (string, error) {
+	tmpID, err := newTempKeyID()
+	if err != nil {
+		return "", err
+	}
+	if err := s.ctx.ImportECDSAPrivateKeyWithLabel(tmpID, []byte(gun), key); err != nil {
+		return "", fmt.Errorf("failed to import key onto token %s: %v", s.tokenLabel, err)
+	}
+
+	fingerprint := string(FingerprintPublicKey(&key.PublicKey))
+	if err := s.ctx.SetKeyPairID(tmpID, []byte(fingerprint)); err != nil {
+		return "", fmt.Errorf("failed to set CKA_ID on imported keypair: %v", err)
+	}
+	return fingerprint, nil
+}
+
+// Remove deletes the keypair identified by fingerprint from the token.
+func (s *PKCS11KeyStore) Remove(fingerprint string) error {
+	signer, err := s.ctx.FindKeyPair([]byte(fingerprint), nil)
+	if err != nil {
+		return fmt.Errorf("failed to find keypair %s on token %s: %v", fingerprint, s.tokenLabel, err)
+	}
+	if signer == nil {
+		return fmt.Errorf("no key found for fingerprint %s", fingerprint)
+	}
+	return s.ctx.DeleteKeyPair(signer)
+}
@@ -0,0 +1,7 @@
+package trustmanager
+
+// DefaultPKCS11ModulePath returns the per-OS default PKCS#11 module path,
+// used when viper's "pkcs11.module" setting is left unset.
+func DefaultPKCS11ModulePath() string {
+	return defaultPKCS11ModulePath
+}
@@ -0,0 +1,8 @@
+//go:build darwin
+// +build darwin
+
+package trustmanager
+
+// defaultPKCS11ModulePath is used when viper's "pkcs11.module" is unset.
+// Homebrew's default install location for SoftHSM2 on macOS.
+const defaultPKCS11ModulePath = "/usr/local/lib/softhsm/libsofthsm2.so"
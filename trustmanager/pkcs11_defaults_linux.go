@@ -0,0 +1,8 @@
+//go:build linux
+// +build linux
+
+package trustmanager
+
+// defaultPKCS11ModulePath is used when viper's "pkcs11.module" is unset.
+// SoftHSM2's default install location on most Linux distributions.
+const defaultPKCS11ModulePath = "/usr/lib/softhsm/libsofthsm2.so"
@@ -0,0 +1,8 @@
+//go:build windows
+// +build windows
+
+package trustmanager
+
+// defaultPKCS11ModulePath is used when viper's "pkcs11.module" is unset.
+// SoftHSM2's default install location on Windows.
+const defaultPKCS11ModulePath = `C:\SoftHSM2\lib\softhsm2.dll`
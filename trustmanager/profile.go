@@ -0,0 +1,108 @@
+package trustmanager
+
+import (
+	"crypto/x509"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// CertProfile bundles the validity window and usage bits that go into a
+// certificate template, so that a named profile (or a set of explicit
+// flags) can be applied uniformly by newCertificate.
+type CertProfile struct {
+	NotBefore   time.Time
+	NotAfter    time.Time
+	KeyUsage    x509.KeyUsage
+	ExtKeyUsage []x509.ExtKeyUsage
+	IsCA        bool
+}
+
+var keyUsageNames = map[string]x509.KeyUsage{
+	"digitalsignature":  x509.KeyUsageDigitalSignature,
+	"contentcommitment": x509.KeyUsageContentCommitment,
+	"keyencipherment":   x509.KeyUsageKeyEncipherment,
+	"dataencipherment":  x509.KeyUsageDataEncipherment,
+	"keyagreement":      x509.KeyUsageKeyAgreement,
+	"certsign":          x509.KeyUsageCertSign,
+	"crlsign":           x509.KeyUsageCRLSign,
+	"encipheronly":      x509.KeyUsageEncipherOnly,
+	"decipheronly":      x509.KeyUsageDecipherOnly,
+}
+
+var extKeyUsageNames = map[string]x509.ExtKeyUsage{
+	"any":                        x509.ExtKeyUsageAny,
+	"serverauth":                 x509.ExtKeyUsageServerAuth,
+	"clientauth":                 x509.ExtKeyUsageClientAuth,
+	"codesigning":                x509.ExtKeyUsageCodeSigning,
+	"emailprotection":            x509.ExtKeyUsageEmailProtection,
+	"timestamping":               x509.ExtKeyUsageTimeStamping,
+	"ocspsigning":                x509.ExtKeyUsageOCSPSigning,
+	"ipsecendsystem":             x509.ExtKeyUsageIPSECEndSystem,
+	"ipsectunnel":                x509.ExtKeyUsageIPSECTunnel,
+	"ipsecuser":                  x509.ExtKeyUsageIPSECUser,
+	"microsoftservergatedcrypto": x509.ExtKeyUsageMicrosoftServerGatedCrypto,
+	"netscapeservergatedcrypto":  x509.ExtKeyUsageNetscapeServerGatedCrypto,
+}
+
+// ParseKeyUsages maps a list of flag strings (case-insensitive) to their
+// x509.KeyUsage bits, ORing them together. An unknown name is an error.
+func ParseKeyUsages(names []string) (x509.KeyUsage, error) {
+	var usage x509.KeyUsage
+	for _, name := range names {
+		bit, ok := keyUsageNames[strings.ToLower(name)]
+		if !ok {
+			return 0, fmt.Errorf("unknown key usage %q", name)
+		}
+		usage |= bit
+	}
+	return usage, nil
+}
+
+// ParseExtKeyUsages maps a list of flag strings (case-insensitive) to their
+// x509.ExtKeyUsage values. An unknown name is an error.
+func ParseExtKeyUsages(names []string) ([]x509.ExtKeyUsage, error) {
+	usages := make([]x509.ExtKeyUsage, 0, len(names))
+	for _, name := range names {
+		usage, ok := extKeyUsageNames[strings.ToLower(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown extended key usage %q", name)
+		}
+		usages = append(usages, usage)
+	}
+	return usages, nil
+}
+
+// namedProfiles are the bundles selectable via `--profile`. Validity
+// windows are filled in by the caller, since "2 years from now" depends on
+// when the profile is applied.
+var namedProfiles = map[string]CertProfile{
+	"codesigning": {
+		KeyUsage:    x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	},
+	"server": {
+		KeyUsage:    x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	},
+	"client": {
+		KeyUsage:    x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	},
+	"ca": {
+		KeyUsage: x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		IsCA:     true,
+	},
+}
+
+// NamedProfile looks up one of the `--profile` shorthands
+// (server|client|codesigning|ca). The returned CertProfile has a zero
+// NotBefore/NotAfter; callers fill those in from --not-before/--not-after
+// or their own defaults.
+func NamedProfile(name string) (CertProfile, error) {
+	profile, ok := namedProfiles[strings.ToLower(name)]
+	if !ok {
+		return CertProfile{}, fmt.Errorf("unknown profile %q: expected one of server, client, codesigning, ca", name)
+	}
+	return profile, nil
+}
@@ -0,0 +1,69 @@
+package trustmanager
+
+import (
+	"crypto/x509"
+	"testing"
+)
+
+func TestParseKeyUsages(t *testing.T) {
+	usage, err := ParseKeyUsages([]string{"DigitalSignature", "certsign"})
+	if err != nil {
+		t.Fatalf("ParseKeyUsages returned unexpected error: %v", err)
+	}
+	want := x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign
+	if usage != want {
+		t.Errorf("got key usage %v, want %v", usage, want)
+	}
+}
+
+func TestParseKeyUsagesUnknown(t *testing.T) {
+	if _, err := ParseKeyUsages([]string{"bogus"}); err == nil {
+		t.Fatal("expected an error for an unknown key usage")
+	}
+}
+
+func TestParseExtKeyUsages(t *testing.T) {
+	usages, err := ParseExtKeyUsages([]string{"ServerAuth", "clientauth"})
+	if err != nil {
+		t.Fatalf("ParseExtKeyUsages returned unexpected error: %v", err)
+	}
+	want := []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}
+	if len(usages) != len(want) {
+		t.Fatalf("got %d ext key usages, want %d", len(usages), len(want))
+	}
+	for i := range want {
+		if usages[i] != want[i] {
+			t.Errorf("usages[%d] = %v, want %v", i, usages[i], want[i])
+		}
+	}
+}
+
+func TestParseExtKeyUsagesUnknown(t *testing.T) {
+	if _, err := ParseExtKeyUsages([]string{"bogus"}); err == nil {
+		t.Fatal("expected an error for an unknown extended key usage")
+	}
+}
+
+func TestNamedProfile(t *testing.T) {
+	for _, name := range []string{"server", "client", "codesigning", "ca"} {
+		if _, err := NamedProfile(name); err != nil {
+			t.Errorf("NamedProfile(%q) returned unexpected error: %v", name, err)
+		}
+	}
+}
+
+func TestNamedProfileCA(t *testing.T) {
+	profile, err := NamedProfile("CA")
+	if err != nil {
+		t.Fatalf("NamedProfile returned unexpected error: %v", err)
+	}
+	if !profile.IsCA {
+		t.Error("expected the ca profile to have IsCA set")
+	}
+}
+
+func TestNamedProfileUnknown(t *testing.T) {
+	if _, err := NamedProfile("bogus"); err == nil {
+		t.Fatal("expected an error for an unknown profile name")
+	}
+}
@@ -0,0 +1,137 @@
+package trustmanager
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// TrustPinConfig is the persisted pinning policy for all GUNs: which exact
+// certificate(s) are pinned to a GUN, which CAs are trusted to sign any GUN
+// matching a prefix, which GUNs have accepted a certificate on trust (TOFU)
+// rather than by explicit pin, and whether trust-on-first-use is still
+// allowed for GUNs that match none of the above.
+type TrustPinConfig struct {
+	Certs       map[string][]string `json:"certs"`
+	CA          map[string]string   `json:"ca"`
+	TOFU        map[string]string   `json:"tofu"`
+	DisableTOFU bool                `json:"disable_tofu"`
+}
+
+const trustPinningFileName = "trust_pinning.json"
+
+// LoadTrustPinConfig reads the pinning policy from
+// <trustDir>/trust_pinning.json, returning an empty config if the file
+// doesn't exist yet.
+func LoadTrustPinConfig(trustDir string) (*TrustPinConfig, error) {
+	raw, err := ioutil.ReadFile(filepath.Join(trustDir, trustPinningFileName))
+	if os.IsNotExist(err) {
+		return &TrustPinConfig{Certs: map[string][]string{}, CA: map[string]string{}, TOFU: map[string]string{}}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read trust pinning config: %v", err)
+	}
+
+	cfg := &TrustPinConfig{}
+	if err := json.Unmarshal(raw, cfg); err != nil {
+		return nil, fmt.Errorf("could not parse trust pinning config: %v", err)
+	}
+	if cfg.Certs == nil {
+		cfg.Certs = map[string][]string{}
+	}
+	if cfg.CA == nil {
+		cfg.CA = map[string]string{}
+	}
+	if cfg.TOFU == nil {
+		cfg.TOFU = map[string]string{}
+	}
+	return cfg, nil
+}
+
+// Save persists cfg to <trustDir>/trust_pinning.json.
+func (cfg *TrustPinConfig) Save(trustDir string) error {
+	raw, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not marshal trust pinning config: %v", err)
+	}
+	if err := os.MkdirAll(trustDir, 0700); err != nil {
+		return fmt.Errorf("could not create trust directory: %v", err)
+	}
+	return ioutil.WriteFile(filepath.Join(trustDir, trustPinningFileName), raw, 0600)
+}
+
+// PinCert pins fingerprint as a certificate trusted for gun, in addition to
+// any already pinned there.
+func (cfg *TrustPinConfig) PinCert(gun, fingerprint string) {
+	for _, fp := range cfg.Certs[gun] {
+		if fp == fingerprint {
+			return
+		}
+	}
+	cfg.Certs[gun] = append(cfg.Certs[gun], fingerprint)
+}
+
+// PinCA trusts fingerprint as a CA allowed to sign any GUN matching prefix.
+func (cfg *TrustPinConfig) PinCA(prefix, fingerprint string) {
+	cfg.CA[prefix] = fingerprint
+}
+
+// PinTOFU records fingerprint as the certificate trusted-on-first-use for
+// gun. A gun may only ever accept one certificate this way: if gun has
+// already accepted a different fingerprint, PinTOFU returns an error rather
+// than silently trusting the new one, so that a changed certificate on the
+// wire is treated as a possible attack rather than a routine rotation. To
+// rotate a TOFU-trusted GUN's certificate on purpose, `keys pinning remove`
+// it first.
+func (cfg *TrustPinConfig) PinTOFU(gun, fingerprint string) error {
+	if existing, ok := cfg.TOFU[gun]; ok {
+		if existing != fingerprint {
+			return fmt.Errorf("refusing trust-on-first-use for %s: already trusts a different certificate (%s)", gun, existing)
+		}
+		return nil
+	}
+	cfg.TOFU[gun] = fingerprint
+	return nil
+}
+
+// Unpin removes every pinning entry recorded for gun: exact cert pins, any
+// CA prefix pin that exactly matches it, and any TOFU acceptance.
+func (cfg *TrustPinConfig) Unpin(gun string) {
+	delete(cfg.Certs, gun)
+	delete(cfg.CA, gun)
+	delete(cfg.TOFU, gun)
+}
+
+// ModeFor reports the pinning mode in effect for gun: "certs" if it has
+// pinned certificates, "ca" if a CA prefix pin covers it, "tofu" if it has
+// trusted a certificate on first use (or would still be allowed to), or ""
+// if DisableTOFU forbids trusting it without an explicit pin.
+func (cfg *TrustPinConfig) ModeFor(gun string) string {
+	if _, ok := cfg.Certs[gun]; ok {
+		return "certs"
+	}
+	for prefix := range cfg.CA {
+		if MatchGUNPrefix(prefix, gun) {
+			return "ca"
+		}
+	}
+	if _, ok := cfg.TOFU[gun]; ok {
+		return "tofu"
+	}
+	if cfg.DisableTOFU {
+		return ""
+	}
+	return "tofu"
+}
+
+// MatchGUNPrefix reports whether gun matches prefix, which may end in "*"
+// to glob-match any suffix.
+func MatchGUNPrefix(prefix, gun string) bool {
+	if strings.HasSuffix(prefix, "*") {
+		return strings.HasPrefix(gun, strings.TrimSuffix(prefix, "*"))
+	}
+	return prefix == gun
+}
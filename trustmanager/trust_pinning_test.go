@@ -0,0 +1,107 @@
+package trustmanager
+
+import "testing"
+
+func TestMatchGUNPrefix(t *testing.T) {
+	cases := []struct {
+		prefix, gun string
+		want        bool
+	}{
+		{"docker.io/library/redis", "docker.io/library/redis", true},
+		{"docker.io/library/redis", "docker.io/library/nginx", false},
+		{"docker.io/library/*", "docker.io/library/redis", true},
+		{"docker.io/library/*", "docker.io/other/redis", false},
+		{"*", "anything", true},
+	}
+	for _, c := range cases {
+		if got := MatchGUNPrefix(c.prefix, c.gun); got != c.want {
+			t.Errorf("MatchGUNPrefix(%q, %q) = %v, want %v", c.prefix, c.gun, got, c.want)
+		}
+	}
+}
+
+func newTestConfig() *TrustPinConfig {
+	return &TrustPinConfig{Certs: map[string][]string{}, CA: map[string]string{}, TOFU: map[string]string{}}
+}
+
+func TestModeForCerts(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.PinCert("docker.io/library/redis", "abc123")
+	if got := cfg.ModeFor("docker.io/library/redis"); got != "certs" {
+		t.Errorf("ModeFor = %q, want \"certs\"", got)
+	}
+}
+
+func TestModeForCA(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.PinCA("docker.io/library/*", "abc123")
+	if got := cfg.ModeFor("docker.io/library/redis"); got != "ca" {
+		t.Errorf("ModeFor = %q, want \"ca\"", got)
+	}
+}
+
+func TestModeForTOFU(t *testing.T) {
+	cfg := newTestConfig()
+	if got := cfg.ModeFor("docker.io/library/redis"); got != "tofu" {
+		t.Errorf("ModeFor on an unpinned GUN = %q, want \"tofu\"", got)
+	}
+
+	if err := cfg.PinTOFU("docker.io/library/redis", "abc123"); err != nil {
+		t.Fatalf("PinTOFU returned unexpected error: %v", err)
+	}
+	if got := cfg.ModeFor("docker.io/library/redis"); got != "tofu" {
+		t.Errorf("ModeFor after PinTOFU = %q, want \"tofu\"", got)
+	}
+}
+
+func TestModeForDisableTOFU(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.DisableTOFU = true
+	if got := cfg.ModeFor("docker.io/library/redis"); got != "" {
+		t.Errorf("ModeFor with DisableTOFU set = %q, want \"\"", got)
+	}
+}
+
+func TestPinTOFUAcceptsRepeatOfSameCert(t *testing.T) {
+	cfg := newTestConfig()
+	if err := cfg.PinTOFU("docker.io/library/redis", "abc123"); err != nil {
+		t.Fatalf("first PinTOFU returned unexpected error: %v", err)
+	}
+	if err := cfg.PinTOFU("docker.io/library/redis", "abc123"); err != nil {
+		t.Errorf("repeat PinTOFU of the same fingerprint returned unexpected error: %v", err)
+	}
+}
+
+func TestPinTOFURejectsMismatch(t *testing.T) {
+	cfg := newTestConfig()
+	if err := cfg.PinTOFU("docker.io/library/redis", "abc123"); err != nil {
+		t.Fatalf("first PinTOFU returned unexpected error: %v", err)
+	}
+	if err := cfg.PinTOFU("docker.io/library/redis", "def456"); err == nil {
+		t.Fatal("expected PinTOFU to reject a second, different certificate for the same GUN")
+	}
+	if got := cfg.TOFU["docker.io/library/redis"]; got != "abc123" {
+		t.Errorf("a rejected PinTOFU must not overwrite the existing entry, got %q", got)
+	}
+}
+
+func TestUnpinRemovesTOFU(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.PinCert("docker.io/library/redis", "abc123")
+	cfg.PinCA("docker.io/library/redis", "abc123")
+	if err := cfg.PinTOFU("docker.io/library/redis", "abc123"); err != nil {
+		t.Fatalf("PinTOFU returned unexpected error: %v", err)
+	}
+
+	cfg.Unpin("docker.io/library/redis")
+
+	if _, ok := cfg.Certs["docker.io/library/redis"]; ok {
+		t.Error("Unpin did not remove the cert pin")
+	}
+	if _, ok := cfg.CA["docker.io/library/redis"]; ok {
+		t.Error("Unpin did not remove the CA pin")
+	}
+	if _, ok := cfg.TOFU["docker.io/library/redis"]; ok {
+		t.Error("Unpin did not remove the TOFU acceptance")
+	}
+}
@@ -0,0 +1,68 @@
+package trustmanager
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseValidity parses the --not-before/--not-after flag values accepted by
+// `keys generate`. Each value is either an RFC3339 timestamp
+// ("2026-01-01T00:00:00Z") or a timespec relative to now, written as a
+// signed Go duration with an additional "d" (day) unit, e.g. "now+30d",
+// "+30d", "-1h". A bare duration is treated the same as one prefixed with
+// "now+".
+func ParseValidity(notBeforeSpec, notAfterSpec string) (notBefore, notAfter time.Time, err error) {
+	now := time.Now()
+
+	notBefore, err = parseTimespec(notBeforeSpec, now)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid --not-before %q: %v", notBeforeSpec, err)
+	}
+	notAfter, err = parseTimespec(notAfterSpec, now)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid --not-after %q: %v", notAfterSpec, err)
+	}
+	if !notAfter.After(notBefore) {
+		return time.Time{}, time.Time{}, fmt.Errorf("--not-after (%s) must be after --not-before (%s)", notAfter, notBefore)
+	}
+	return notBefore, notAfter, nil
+}
+
+func parseTimespec(spec string, now time.Time) (time.Time, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return time.Time{}, fmt.Errorf("empty timespec")
+	}
+
+	if t, err := time.Parse(time.RFC3339, spec); err == nil {
+		return t, nil
+	}
+
+	rel := strings.TrimPrefix(spec, "now")
+	if rel == "" {
+		return now, nil
+	}
+
+	d, err := parseDuration(rel)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected an RFC3339 timestamp or a duration like \"+30d\", \"-1h\": %v", err)
+	}
+	return now.Add(d), nil
+}
+
+// parseDuration extends time.ParseDuration with a "d" (24h day) unit, since
+// certificate validity windows are usually expressed in days.
+func parseDuration(spec string) (time.Duration, error) {
+	if !strings.HasSuffix(spec, "d") {
+		return time.ParseDuration(spec)
+	}
+
+	numeric := strings.TrimSuffix(spec, "d")
+	days, err := strconv.ParseFloat(numeric, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid day count %q: %v", numeric, err)
+	}
+	return time.Duration(days * 24 * float64(time.Hour)), nil
+}
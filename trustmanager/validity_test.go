@@ -0,0 +1,78 @@
+package trustmanager
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseValidity(t *testing.T) {
+	notBefore, notAfter, err := ParseValidity("now", "+30d")
+	if err != nil {
+		t.Fatalf("ParseValidity returned unexpected error: %v", err)
+	}
+	if !notAfter.After(notBefore) {
+		t.Fatalf("expected not-after (%s) to be after not-before (%s)", notAfter, notBefore)
+	}
+	if got, want := notAfter.Sub(notBefore), 30*24*time.Hour; got != want {
+		t.Errorf("got validity window %s, want %s", got, want)
+	}
+}
+
+func TestParseValidityRFC3339(t *testing.T) {
+	notBefore, notAfter, err := ParseValidity("2026-01-01T00:00:00Z", "2026-02-01T00:00:00Z")
+	if err != nil {
+		t.Fatalf("ParseValidity returned unexpected error: %v", err)
+	}
+	if !notBefore.Equal(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("got not-before %s, want 2026-01-01T00:00:00Z", notBefore)
+	}
+	if !notAfter.Equal(time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("got not-after %s, want 2026-02-01T00:00:00Z", notAfter)
+	}
+}
+
+func TestParseValidityRejectsNotAfterBeforeNotBefore(t *testing.T) {
+	if _, _, err := ParseValidity("now", "-1h"); err == nil {
+		t.Fatal("expected an error when --not-after is before --not-before")
+	}
+}
+
+func TestParseValidityRejectsEmptySpec(t *testing.T) {
+	if _, _, err := ParseValidity("", "+30d"); err == nil {
+		t.Fatal("expected an error for an empty --not-before spec")
+	}
+}
+
+func TestParseValidityRejectsGarbage(t *testing.T) {
+	if _, _, err := ParseValidity("now", "not-a-timespec"); err == nil {
+		t.Fatal("expected an error for an unparseable --not-after spec")
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	cases := []struct {
+		spec string
+		want time.Duration
+	}{
+		{"30d", 30 * 24 * time.Hour},
+		{"1.5d", 36 * time.Hour},
+		{"1h", time.Hour},
+		{"-1h", -time.Hour},
+	}
+	for _, c := range cases {
+		got, err := parseDuration(c.spec)
+		if err != nil {
+			t.Errorf("parseDuration(%q) returned unexpected error: %v", c.spec, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("parseDuration(%q) = %s, want %s", c.spec, got, c.want)
+		}
+	}
+}
+
+func TestParseDurationRejectsInvalidDayCount(t *testing.T) {
+	if _, err := parseDuration("xd"); err == nil {
+		t.Fatal("expected an error for a non-numeric day count")
+	}
+}